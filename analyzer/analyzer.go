@@ -0,0 +1,173 @@
+// Package analyzer 在 Process 和 Write 之间插入一个轻量的统计/告警阶段:按 (Path,Method)
+// 维护滑动窗口内的请求量、错误率和近似的 p50/p95/p99 延迟,不依赖 Grafana 就能发现异常。
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"LogAnalytics/message"
+)
+
+// Config 描述窗口大小、告警阈值,以及从 Message 里取哪些字段来算统计
+type Config struct {
+	Window time.Duration // 滑动窗口大小,比如 1 分钟
+
+	PathTag    string // Tags 里表示 path 的 key,默认 "path"
+	MethodTag  string // Tags 里表示 method 的 key,默认 "method"
+	StatusTag  string // Tags 里表示 http status 的 key,默认 "status"
+	LatencyKey string // Fields 里表示延迟的 key,默认 "request_time"
+
+	ErrorRateThreshold float64       // 错误率超过这个值就告警,比如 0.05
+	MADMultiplier      float64       // p99 偏离 baseline 超过 k*MAD 就告警
+	AlertCooldown      time.Duration // 同一个key同一种告警多久之内不重复发
+	MinSamples         int64         // 窗口内样本数不到这个值,先不做告警判断
+}
+
+// DefaultConfig 返回一组适合直接拿来用的默认参数
+func DefaultConfig() Config {
+	return Config{
+		Window:             1 * time.Minute,
+		PathTag:            "path",
+		MethodTag:          "method",
+		StatusTag:          "status",
+		LatencyKey:         "request_time",
+		ErrorRateThreshold: 0.05,
+		MADMultiplier:      3,
+		AlertCooldown:      1 * time.Minute,
+		MinSamples:         20,
+	}
+}
+
+// Analyzer 对流经的每一条 message.Message 做统计,命中阈值就推给 notifiers
+type Analyzer struct {
+	cfg       Config
+	notifiers []Notifier
+
+	mu   sync.Mutex
+	keys map[string]*keyStats
+}
+
+// New 构造一个 Analyzer,notifiers 为空时只统计不告警
+func New(cfg Config, notifiers ...Notifier) *Analyzer {
+	return &Analyzer{
+		cfg:       cfg,
+		notifiers: notifiers,
+		keys:      map[string]*keyStats{},
+	}
+}
+
+// Observe 更新一条消息对应 key 的窗口统计,并在命中阈值时发送告警,不会阻塞 Write 阶段
+func (a *Analyzer) Observe(m *message.Message) {
+	key := m.Tags[a.cfg.PathTag] + "|" + m.Tags[a.cfg.MethodTag]
+	now := time.Now()
+
+	a.mu.Lock()
+	stats, ok := a.keys[key]
+	if !ok {
+		stats = newKeyStats(now)
+		a.keys[key] = stats
+	}
+	if now.Sub(stats.windowStart) >= a.cfg.Window {
+		stats.rotate(now)
+	}
+
+	stats.count++
+	if isErrorStatus(m.Tags[a.cfg.StatusTag]) {
+		stats.errCount++
+	}
+	if latency, ok := latencyOf(m.Fields[a.cfg.LatencyKey]); ok {
+		stats.p50.Observe(latency)
+		stats.p95.Observe(latency)
+		stats.p99.Observe(latency)
+	}
+
+	a.checkAlerts(key, stats, now)
+	a.mu.Unlock()
+}
+
+// checkAlerts 在持有 a.mu 的情况下调用,检查错误率和 p99 偏离,命中 cooldown 外的阈值就告警
+func (a *Analyzer) checkAlerts(key string, stats *keyStats, now time.Time) {
+	if stats.count < a.cfg.MinSamples {
+		return
+	}
+	if now.Sub(stats.lastAlertAt) < a.cfg.AlertCooldown {
+		return
+	}
+
+	if rate := stats.errorRate(); rate > a.cfg.ErrorRateThreshold {
+		stats.lastAlertAt = now
+		a.dispatch(Alert{Key: key, Reason: "error_rate", Value: rate, Threshold: a.cfg.ErrorRateThreshold, Time: now})
+		return
+	}
+
+	if median, mad, ok := stats.baselineMedianAndMAD(); ok && mad > 0 {
+		p99 := stats.p99.Value()
+		deviation := absFloat(p99-median) / mad
+		if deviation > a.cfg.MADMultiplier {
+			stats.lastAlertAt = now
+			a.dispatch(Alert{Key: key, Reason: "p99_deviation", Value: p99, Threshold: median + a.cfg.MADMultiplier*mad, Time: now})
+		}
+	}
+}
+
+// dispatch 把一条 Alert 送给所有配置的 notifier,单个 notifier 失败不影响其它
+func (a *Analyzer) dispatch(alert Alert) {
+	for _, n := range a.notifiers {
+		go func(n Notifier) {
+			_ = n.Notify(alert)
+		}(n)
+	}
+}
+
+// statSnapshot 是 /stats 接口返回的单个key的快照
+type statSnapshot struct {
+	Key        string  `json:"key"`
+	Count      int64   `json:"count"`
+	ErrorRate  float64 `json:"errorRate"`
+	P50        float64 `json:"p50"`
+	P95        float64 `json:"p95"`
+	P99        float64 `json:"p99"`
+	WindowSecs float64 `json:"windowSecs"`
+}
+
+// Handler 返回一个 http.HandlerFunc,把当前所有key的窗口统计以 JSON 形式吐出来
+func (a *Analyzer) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.mu.Lock()
+		snapshots := make([]statSnapshot, 0, len(a.keys))
+		now := time.Now()
+		for key, stats := range a.keys {
+			snapshots = append(snapshots, statSnapshot{
+				Key:        key,
+				Count:      stats.count,
+				ErrorRate:  stats.errorRate(),
+				P50:        stats.p50.Value(),
+				P95:        stats.p95.Value(),
+				P99:        stats.p99.Value(),
+				WindowSecs: now.Sub(stats.windowStart).Seconds(),
+			})
+		}
+		a.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshots)
+	}
+}
+
+func isErrorStatus(status string) bool {
+	return len(status) == 3 && status[0] == '5'
+}
+
+func latencyOf(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb1-client/v2"
+)
+
+// Alert 是 analyzer 检测到异常时产出的事件,Notifier 负责把它送到外部系统。
+type Alert struct {
+	Key       string    `json:"key"`    // Path|Method
+	Reason    string    `json:"reason"` // error_rate | p99_deviation
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// Notifier 接口 定义了把一条 Alert 送出去的规范,webhook/slack/influxdb 各自实现
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// WebhookNotifier 把 Alert 序列化成 JSON,POST 给一个通用的 webhook 地址
+type WebhookNotifier struct {
+	URL    string
+	client http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url}
+}
+
+func (w *WebhookNotifier) Notify(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook notifier: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SlackNotifier 把 Alert 格式化成一条文本消息,发给 Slack 的 incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	client     http.Client
+}
+
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{WebhookURL: webhookURL}
+}
+
+func (s *SlackNotifier) Notify(alert Alert) error {
+	text := fmt.Sprintf("[loganalytics] %s on %s: value=%.3f threshold=%.3f at %s",
+		alert.Reason, alert.Key, alert.Value, alert.Threshold, alert.Time.Format(time.RFC3339))
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("slack notifier: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InfluxAlertNotifier 把 Alert 写进 influxdb 的一个独立 measurement,方便在 Grafana 里
+// 跟其它业务指标放在一起看,dsn 格式和 output/influxdb.go 的 -influxDsn 一样。
+type InfluxAlertNotifier struct {
+	measurement string
+	c           client.Client
+	database    string
+	precision   string
+}
+
+func NewInfluxAlertNotifier(dsn string) (*InfluxAlertNotifier, error) {
+	parts := strings.Split(dsn, "@")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("influx alert notifier: invalid dsn %q", dsn)
+	}
+	c, err := client.NewHTTPClient(client.HTTPConfig{Addr: parts[0], Username: parts[1], Password: parts[2]})
+	if err != nil {
+		return nil, err
+	}
+	return &InfluxAlertNotifier{measurement: "alerts", c: c, database: parts[3], precision: parts[4]}, nil
+}
+
+func (n *InfluxAlertNotifier) Notify(alert Alert) error {
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{Database: n.database, Precision: n.precision})
+	if err != nil {
+		return err
+	}
+	pt, err := client.NewPoint(n.measurement,
+		map[string]string{"key": alert.Key, "reason": alert.Reason},
+		map[string]interface{}{"value": alert.Value, "threshold": alert.Threshold},
+		alert.Time)
+	if err != nil {
+		return err
+	}
+	bp.AddPoint(pt)
+	return n.c.Write(bp)
+}
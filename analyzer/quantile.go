@@ -0,0 +1,110 @@
+package analyzer
+
+import "sort"
+
+// p2Quantile 是 P² (Piecewise-Parabolic) 流式分位数算法的实现,只维护5个marker,
+// 每个key每个分位数占用 O(1) 内存,不需要保留原始样本。参考 Jain & Chlamtac 1985。
+type p2Quantile struct {
+	p float64 // 目标分位数,例如 p99 就是 0.99
+
+	n           int        // 已经看到的样本数
+	initSamples []float64  // 前5个样本先缓存起来,凑够5个再初始化marker
+	q           [5]float64 // marker的高度(也就是分位数估计值)
+	pos         [5]float64 // marker当前的位置(第几个样本)
+	np          [5]float64 // marker期望的位置
+	dn          [5]float64 // 每来一个样本,期望位置该增加多少
+}
+
+func newP2Quantile(p float64) *p2Quantile {
+	return &p2Quantile{
+		p:  p,
+		dn: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+// Observe 喂给算法一个新样本
+func (q *p2Quantile) Observe(x float64) {
+	q.n++
+
+	if len(q.initSamples) < 5 {
+		q.initSamples = append(q.initSamples, x)
+		if len(q.initSamples) == 5 {
+			sort.Float64s(q.initSamples)
+			for i := 0; i < 5; i++ {
+				q.q[i] = q.initSamples[i]
+				q.pos[i] = float64(i + 1)
+			}
+			q.np = [5]float64{1, 1 + 2*q.p, 1 + 4*q.p, 3 + 2*q.p, 5}
+		}
+		return
+	}
+
+	// 1. 找到 x 落在哪个区间,必要时扩展两端的marker
+	var k int
+	switch {
+	case x < q.q[0]:
+		q.q[0] = x
+		k = 0
+	case x >= q.q[4]:
+		q.q[4] = x
+		k = 3
+	default:
+		k = 0
+		for i := 0; i < 4; i++ {
+			if x < q.q[i+1] {
+				k = i
+				break
+			}
+		}
+	}
+
+	// 2. 更新marker位置
+	for i := k + 1; i < 5; i++ {
+		q.pos[i]++
+	}
+	for i := 0; i < 5; i++ {
+		q.np[i] += q.dn[i]
+	}
+
+	// 3. 调整中间3个marker的高度,保持和期望位置一致
+	for i := 1; i < 4; i++ {
+		d := q.np[i] - q.pos[i]
+		if (d >= 1 && q.pos[i+1]-q.pos[i] > 1) || (d <= -1 && q.pos[i-1]-q.pos[i] < -1) {
+			sign := 1.0
+			if d < 0 {
+				sign = -1.0
+			}
+			newQ := q.parabolic(i, sign)
+			if q.q[i-1] < newQ && newQ < q.q[i+1] {
+				q.q[i] = newQ
+			} else {
+				q.q[i] = q.linear(i, sign)
+			}
+			q.pos[i] += sign
+		}
+	}
+}
+
+func (q *p2Quantile) parabolic(i int, d float64) float64 {
+	return q.q[i] + d/(q.pos[i+1]-q.pos[i-1])*
+		((q.pos[i]-q.pos[i-1]+d)*(q.q[i+1]-q.q[i])/(q.pos[i+1]-q.pos[i])+
+			(q.pos[i+1]-q.pos[i]-d)*(q.q[i]-q.q[i-1])/(q.pos[i]-q.pos[i-1]))
+}
+
+func (q *p2Quantile) linear(i int, d float64) float64 {
+	return q.q[i] + d*(q.q[int(float64(i)+d)]-q.q[i])/(q.pos[int(float64(i)+d)]-q.pos[i])
+}
+
+// Value 返回当前的分位数估计值;样本数不足5个时用排序后的原始样本近似
+func (q *p2Quantile) Value() float64 {
+	if len(q.initSamples) < 5 {
+		if len(q.initSamples) == 0 {
+			return 0
+		}
+		sorted := append([]float64(nil), q.initSamples...)
+		sort.Float64s(sorted)
+		idx := int(q.p * float64(len(sorted)-1))
+		return sorted[idx]
+	}
+	return q.q[2]
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import "time"
+
+// keyStats 维护某个 (Path,Method) 组合在当前滑动窗口内的统计,P²分位数本身是全量估计,
+// 所以这里按 window 周期性重置来模拟滑动窗口,旧窗口结束时把 p99 存进 baseline 历史里,
+// 供下一个窗口做 MAD 异常检测。
+type keyStats struct {
+	windowStart time.Time
+
+	count    int64
+	errCount int64
+
+	p50 *p2Quantile
+	p95 *p2Quantile
+	p99 *p2Quantile
+
+	p99Baseline []float64 // 最近几个窗口结束时的 p99,用来算基线和 MAD
+
+	lastAlertAt time.Time // 同一个key在 cooldown 内不重复告警
+}
+
+const p99BaselineSize = 10
+
+func newKeyStats(now time.Time) *keyStats {
+	return &keyStats{
+		windowStart: now,
+		p50:         newP2Quantile(0.5),
+		p95:         newP2Quantile(0.95),
+		p99:         newP2Quantile(0.99),
+	}
+}
+
+// rotate 在窗口到期时把当前 p99 存进 baseline,重置计数和分位数,开始新窗口
+func (k *keyStats) rotate(now time.Time) {
+	k.p99Baseline = append(k.p99Baseline, k.p99.Value())
+	if len(k.p99Baseline) > p99BaselineSize {
+		k.p99Baseline = k.p99Baseline[1:]
+	}
+
+	k.windowStart = now
+	k.count = 0
+	k.errCount = 0
+	k.p50 = newP2Quantile(0.5)
+	k.p95 = newP2Quantile(0.95)
+	k.p99 = newP2Quantile(0.99)
+}
+
+func (k *keyStats) errorRate() float64 {
+	if k.count == 0 {
+		return 0
+	}
+	return float64(k.errCount) / float64(k.count)
+}
+
+// baselineMedianAndMAD 返回 p99Baseline 的中位数和 median absolute deviation
+func (k *keyStats) baselineMedianAndMAD() (median, mad float64, ok bool) {
+	if len(k.p99Baseline) < 3 {
+		return 0, 0, false
+	}
+
+	sorted := append([]float64(nil), k.p99Baseline...)
+	median = medianOf(sorted)
+
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = absFloat(v - median)
+	}
+	mad = medianOf(deviations)
+	return median, mad, true
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyStatsErrorRate(t *testing.T) {
+	k := newKeyStats(time.Now())
+	if got := k.errorRate(); got != 0 {
+		t.Fatalf("errorRate of empty window = %v, want 0", got)
+	}
+
+	k.count = 10
+	k.errCount = 2
+	if got, want := k.errorRate(), 0.2; got != want {
+		t.Fatalf("errorRate = %v, want %v", got, want)
+	}
+}
+
+func TestKeyStatsBaselineMedianAndMAD(t *testing.T) {
+	k := newKeyStats(time.Now())
+
+	if _, _, ok := k.baselineMedianAndMAD(); ok {
+		t.Fatalf("baseline should not be ready with fewer than 3 samples")
+	}
+
+	k.p99Baseline = []float64{10, 12, 11, 50, 13}
+	median, mad, ok := k.baselineMedianAndMAD()
+	if !ok {
+		t.Fatalf("baseline should be ready with 5 samples")
+	}
+	if median != 12 {
+		t.Errorf("median = %v, want 12", median)
+	}
+	if mad != 1 {
+		t.Errorf("mad = %v, want 1", mad)
+	}
+}
+
+func TestKeyStatsRotate(t *testing.T) {
+	now := time.Now()
+	k := newKeyStats(now)
+	k.count = 5
+	k.errCount = 1
+	k.p99.Observe(100)
+
+	later := now.Add(time.Minute)
+	k.rotate(later)
+
+	if k.count != 0 || k.errCount != 0 {
+		t.Fatalf("rotate should reset counters, got count=%d errCount=%d", k.count, k.errCount)
+	}
+	if k.windowStart != later {
+		t.Fatalf("rotate should update windowStart to %v, got %v", later, k.windowStart)
+	}
+	if len(k.p99Baseline) != 1 {
+		t.Fatalf("rotate should record the outgoing p99 into the baseline, got %v", k.p99Baseline)
+	}
+}
@@ -0,0 +1,57 @@
+// Package config 加载 -config 指定的 YAML 配置文件,目前只描述 parser 应该怎么解析日志。
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 是 app.yaml 的顶层结构
+type Config struct {
+	Parser ParserConfig `yaml:"parser"`
+}
+
+// ParserConfig 描述用哪个 parser、按什么规则把一行日志拆成 Tags/Fields
+type ParserConfig struct {
+	// Type 是 parser 的类型: regex | grok | json
+	Type string `yaml:"type"`
+
+	// Pattern 是 regex/grok 用的模式串,json 类型不需要
+	Pattern string `yaml:"pattern"`
+
+	// TimeField 是承载时间戳的字段名(命名捕获组名字,或者 json 里的 key)
+	TimeField string `yaml:"time_field"`
+	// TimeLayout 是解析 TimeField 用的 Go time layout
+	TimeLayout string `yaml:"time_layout"`
+	// Timezone 是解析 TimeField 时使用的时区,空则使用 UTC
+	Timezone string `yaml:"timezone"`
+
+	// Fields 描述除了 TimeField 之外,每个捕获组/json字段最终落到 Tags 还是 Fields 里
+	Fields []FieldConfig `yaml:"fields"`
+}
+
+// FieldConfig 描述一个字段的去向
+type FieldConfig struct {
+	// Name 是命名捕获组的名字,或者 json 类型时源 json 字段的 key
+	Name string `yaml:"name"`
+	// Column 是这个字段的去向: tag | field,默认为 tag
+	Column string `yaml:"column"`
+	// Type 是 field 的值类型: string | int | float,默认为 string,只有 Column 为 field 时才有意义
+	Type string `yaml:"type"`
+}
+
+// Load 读取并解析 YAML 配置文件
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,87 @@
+package input
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// backfillRotated 在检测到日志被 logrotate 轮转之后,尝试把旧文件里还没读到的部分补上。
+// logrotate 常见命名是 path.1 / path.1.gz / path.1.zst,按这个顺序找第一个存在的文件,
+// 从 fromOffset 开始读到 EOF,每读完一行就调用 emit。
+func backfillRotated(path string, fromOffset int64, emit func(line []byte)) error {
+	candidates := []string{path + ".1", path + ".1.gz", path + ".1.zst"}
+
+	for _, c := range candidates {
+		f, err := os.Open(c)
+		if err != nil {
+			continue
+		}
+
+		var r io.Reader = f
+		compressed := false
+		switch {
+		case hasSuffix(c, ".gz"):
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("backfill: gzip open %s: %w", c, err)
+			}
+			defer gz.Close()
+			r = gz
+			compressed = true
+		case hasSuffix(c, ".zst"):
+			zr, err := zstd.NewReader(f)
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("backfill: zstd open %s: %w", c, err)
+			}
+			defer zr.Close()
+			r = zr
+			compressed = true
+		default:
+			// 未压缩的轮转文件可以直接 seek 到旧偏移量续读
+			if _, err := f.Seek(fromOffset, io.SeekStart); err != nil {
+				f.Close()
+				return fmt.Errorf("backfill: seek %s: %w", c, err)
+			}
+		}
+		defer f.Close()
+
+		// 压缩归档没法直接 seek,先把旧偏移量之前的内容解压丢弃掉
+		if compressed && fromOffset > 0 {
+			if _, err := io.CopyN(io.Discard, r, fromOffset); err != nil && err != io.EOF {
+				return fmt.Errorf("backfill: skip %s: %w", c, err)
+			}
+		}
+
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if line[len(line)-1] == '\n' {
+					line = line[:len(line)-1]
+				}
+				emit(line)
+			}
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("backfill: read %s: %w", c, err)
+			}
+		}
+
+		return nil
+	}
+
+	// 没有轮转归档可以补读,不算错误,只是没有更多数据
+	return nil
+}
+
+func hasSuffix(s, suffix string) bool {
+	return len(s) >= len(suffix) && s[len(s)-len(suffix):] == suffix
+}
@@ -0,0 +1,51 @@
+package input
+
+import (
+	"encoding/json"
+	"os"
+	"sync/atomic"
+)
+
+// checkpoint 记录了上一次读到哪个文件的哪个字节偏移,重启后据此续读,
+// 而不是像原来那样每次都从文件末尾重新开始。
+type checkpoint struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+func loadCheckpoint(path string) (checkpoint, bool) {
+	var cp checkpoint
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cp, false
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, false
+	}
+	return cp, true
+}
+
+func saveCheckpoint(path string, cp checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkpointLagBytes 是 ReadFromFile 当前落后文件末尾的字节数,通过 CheckpointLagBytes 对外暴露,
+// 供 monitor 的 SystemInfo 展示。
+var checkpointLagBytes int64
+
+// CheckpointLagBytes 返回当前 tailer 落后于文件末尾的字节数
+func CheckpointLagBytes() int64 {
+	return atomic.LoadInt64(&checkpointLagBytes)
+}
+
+func setCheckpointLagBytes(n int64) {
+	atomic.StoreInt64(&checkpointLagBytes, n)
+}
@@ -0,0 +1,234 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"syscall"
+	"time"
+)
+
+func init() {
+	Register("file", func(cfg Config) (Reader, error) {
+		return &ReadFromFile{path: cfg.Path, fromBeginning: cfg.FromBeginning}, nil
+	})
+}
+
+const (
+	statInterval       = 1 * time.Second
+	checkpointLines    = 200
+	checkpointInterval = 2 * time.Second
+)
+
+// ReadFromFile 是一个带 checkpoint 的 tailer:记录读到了哪个 inode 的哪个字节偏移,
+// 重启后从 checkpoint 续读,并且能发现 logrotate 式的文件轮转并重新打开文件。
+type ReadFromFile struct {
+	path          string // 读取文件的路径
+	fromBeginning bool   // 没有 checkpoint 时,是否从文件头开始读取
+
+	checkpointPath string
+}
+
+// Read方法，用于读取日志，绑定到了ReadFromFile结构体中，相当于面向对象中的类方法。
+// 出错时返回 error 而不是 panic,交给调用方决定是否带着 backoff 重启;
+// ctx 被取消时落一次 checkpoint 再返回 nil。
+func (r *ReadFromFile) Read(ctx context.Context, rc chan []byte) error {
+	if r.checkpointPath == "" {
+		r.checkpointPath = r.path + ".checkpoint"
+	}
+
+	f, offset, err := r.openAndSeek(ctx, rc)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+
+	// pending 保存跨 EOF 轮询累积的、还没等到换行符的半行:bufio.Reader.ReadBytes
+	// 在遇到 EOF 时会把已经从内部缓冲区读出来的字节连同错误一起返回,这些字节已经从
+	// 缓冲区里移除了,如果直接丢弃,后续写入完成时下一次 ReadBytes 只能读到新追加的
+	// 那一截,导致行被截断。所以这里要把它们先攒起来,等真正凑齐一整行再一起发出去。
+	var pending []byte
+
+	var inode uint64
+	if st, err := f.Stat(); err == nil {
+		inode = inodeOf(st)
+	}
+
+	statTicker := time.NewTicker(statInterval)
+	cpTicker := time.NewTicker(checkpointInterval)
+	defer statTicker.Stop()
+	defer cpTicker.Stop()
+
+	linesSinceCheckpoint := 0
+	flush := func() {
+		if err := saveCheckpoint(r.checkpointPath, checkpoint{Inode: inode, Offset: offset}); err != nil {
+			log.Println("checkpoint: save error:", err.Error())
+		}
+		linesSinceCheckpoint = 0
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return nil
+		case <-statTicker.C:
+			if rotated, newInode := r.detectRotation(f, inode); rotated {
+				oldOffset := offset
+				f, offset, inode, err = r.reopenAfterRotation(ctx, rc, oldOffset, newInode)
+				if err != nil {
+					return err
+				}
+				reader = bufio.NewReader(f)
+				pending = nil
+				flush()
+				continue
+			}
+		case <-cpTicker.C:
+			flush()
+		default:
+		}
+
+		line, err := reader.ReadBytes('\n')
+
+		if err == io.EOF {
+			// line 里的字节已经从 bufio 的内部缓冲区里被取走了,不能丢,先攒进 pending,
+			// 等下次读到换行符时和后续内容拼成完整的一行再发出去。
+			if len(line) > 0 {
+				pending = append(pending, line...)
+				offset += int64(len(line))
+			}
+			if size := sizeOf(f); size >= 0 {
+				setCheckpointLagBytes(size - offset)
+			}
+			select {
+			case <-ctx.Done():
+				flush()
+				return nil
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		} else if err != nil {
+			return fmt.Errorf("ReadBytes error:%s", err.Error())
+		}
+
+		offset += int64(len(line))
+		if len(pending) > 0 {
+			line = append(pending, line...)
+			pending = nil
+		}
+		select {
+		case rc <- line[:len(line)-1]:
+		case <-ctx.Done():
+			flush()
+			return nil
+		}
+
+		linesSinceCheckpoint++
+		if linesSinceCheckpoint >= checkpointLines {
+			flush()
+		}
+	}
+}
+
+// openAndSeek 打开文件,优先从 checkpoint 续读;如果没有 checkpoint 或者文件 inode
+// 和 checkpoint 里记录的不一致(说明期间发生过轮转),再按 fromBeginning 决定起点。
+func (r *ReadFromFile) openAndSeek(ctx context.Context, rc chan []byte) (*os.File, int64, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open file error:%s", err.Error())
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("stat file error:%s", err.Error())
+	}
+	inode := inodeOf(st)
+
+	if cp, ok := loadCheckpoint(r.checkpointPath); ok {
+		if cp.Inode == inode {
+			if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+				return nil, 0, fmt.Errorf("seek file error:%s", err.Error())
+			}
+			return f, cp.Offset, nil
+		}
+		// inode 变了,说明进程停止期间文件被轮转过,尝试从旧归档里把落下的部分补上
+		if err := backfillRotated(r.path, cp.Offset, emitOrDone(ctx, rc)); err != nil {
+			log.Println("backfill:", err.Error())
+		}
+	}
+
+	if r.fromBeginning {
+		return f, 0, nil
+	}
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, fmt.Errorf("seek file error:%s", err.Error())
+	}
+	return f, offset, nil
+}
+
+// detectRotation 通过比较 path 当前的 inode/大小 和已打开文件的 inode 判断是否发生了轮转,
+// 或者文件被原地截断(size 变小)。
+func (r *ReadFromFile) detectRotation(f *os.File, inode uint64) (bool, uint64) {
+	st, err := os.Stat(r.path)
+	if err != nil {
+		return false, 0
+	}
+	newInode := inodeOf(st)
+	if newInode != inode {
+		return true, newInode
+	}
+
+	curSize := sizeOf(f)
+	if curSize >= 0 && st.Size() < curSize {
+		// 原地截断,当成一次轮转处理,重新从头打开
+		return true, newInode
+	}
+	return false, 0
+}
+
+// reopenAfterRotation 在发现轮转之后,先把旧文件里还没读完的内容读完并发给 rc,
+// 再尝试从压缩归档里补齐 checkpoint 之后、旧文件关闭之前那一段,最后打开新文件。
+func (r *ReadFromFile) reopenAfterRotation(ctx context.Context, rc chan []byte, oldOffset int64, newInode uint64) (*os.File, int64, uint64, error) {
+	if err := backfillRotated(r.path, oldOffset, emitOrDone(ctx, rc)); err != nil {
+		log.Println("backfill:", err.Error())
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("reopen file error:%s", err.Error())
+	}
+	return f, 0, newInode, nil
+}
+
+// emitOrDone 把 backfillRotated 要求的 emit 回调包一层 ctx 取消检查,ctx 一旦被取消,
+// 剩下的行就地丢弃而不是继续往 rc 里塞,避免 backfill 在关闭过程中卡在一个已经没有
+// 消费者在读的 channel 上。
+func emitOrDone(ctx context.Context, rc chan []byte) func(line []byte) {
+	return func(line []byte) {
+		select {
+		case rc <- line:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func inodeOf(st os.FileInfo) uint64 {
+	if sys, ok := st.Sys().(*syscall.Stat_t); ok {
+		return sys.Ino
+	}
+	return 0
+}
+
+func sizeOf(f *os.File) int64 {
+	st, err := f.Stat()
+	if err != nil {
+		return -1
+	}
+	return st.Size()
+}
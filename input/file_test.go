@@ -0,0 +1,56 @@
+package input
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestReadFromFileReassemblesPartialWrite covers a line that arrives in two
+// separate writes (buffered app writers, partial syscalls, ...): the first
+// write leaves no trailing newline, so ReadBytes hits io.EOF mid-line. Those
+// bytes are already gone from bufio's internal buffer and must be stashed
+// rather than dropped, or the eventual full line comes out truncated to just
+// the suffix.
+func TestReadFromFileReassemblesPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/access.log"
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString("partial-prefix"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &ReadFromFile{path: path, fromBeginning: true}
+	rc := make(chan []byte, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Read(ctx, rc)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 给第一次 ReadBytes 一点时间跑到 EOF,确保它是在半行的状态下被打断的。
+	time.Sleep(200 * time.Millisecond)
+	if _, err := f.WriteString("-suffix\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case line := <-rc:
+		if string(line) != "partial-prefix-suffix" {
+			t.Fatalf("got %q, want %q", line, "partial-prefix-suffix")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the reassembled line")
+	}
+}
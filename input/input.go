@@ -0,0 +1,47 @@
+// Package input 收拢所有日志来源的实现,通过名字注册,方便用 -input 参数切换。
+package input
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reader 接口 定义了Read方法的规范,所有的输入backend都需要实现这个接口。
+// Read 在 ctx 被取消时应当尽快返回 nil,而不是 panic 或者一直阻塞;
+// 其它错误(比如连接断开)应该作为返回值交给调用方决定是否重启。
+type Reader interface {
+	Read(ctx context.Context, rc chan []byte) error
+}
+
+// Config 是构造某个 Reader 所需要的全部参数,具体字段由各 backend 按需读取。
+type Config struct {
+	// Path 文件路径,file backend 使用
+	Path string
+	// FromBeginning 为 true 时从文件头开始读取,否则从末尾开始
+	FromBeginning bool
+
+	// Brokers kafka backend 使用的 broker 地址列表
+	Brokers []string
+	// Topic kafka backend 订阅的 topic
+	Topic string
+	// GroupID kafka backend 使用的 consumer group
+	GroupID string
+}
+
+type factory func(cfg Config) (Reader, error)
+
+var registry = map[string]factory{}
+
+// Register 把一个 backend 的构造函数注册到名字上,backend 的 init() 里调用
+func Register(name string, f factory) {
+	registry[name] = f
+}
+
+// New 根据名字构造一个 Reader,名字来自 -input 参数
+func New(name string, cfg Config) (Reader, error) {
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("input: unknown backend %q", name)
+	}
+	return f(cfg)
+}
@@ -0,0 +1,77 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Shopify/sarama"
+)
+
+func init() {
+	Register("kafka", func(cfg Config) (Reader, error) {
+		if len(cfg.Brokers) == 0 {
+			return nil, fmt.Errorf("kafka input: -kafka-brokers is required")
+		}
+		if cfg.Topic == "" {
+			return nil, fmt.Errorf("kafka input: -kafka-topic is required")
+		}
+		if cfg.GroupID == "" {
+			cfg.GroupID = "loganalytics"
+		}
+		return &KafkaReader{brokers: cfg.Brokers, topic: cfg.Topic, groupID: cfg.GroupID}, nil
+	})
+}
+
+// KafkaReader 从 kafka topic 里消费日志行,适用于 Fluent Bit/Filebeat 已经把日志
+// 集中到 kafka 的场景,此时不需要再在每台机器上 tail 文件。
+type KafkaReader struct {
+	brokers []string
+	topic   string
+	groupID string
+}
+
+// Read方法，用consumer group 消费 topic 里的每条消息,把消息内容原样丢进 rc。
+// ctx 被取消时停止消费并返回 nil,交给调用方的 supervisor 处理带 backoff 的重启。
+func (k *KafkaReader) Read(ctx context.Context, rc chan []byte) error {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	group, err := sarama.NewConsumerGroup(k.brokers, k.groupID, cfg)
+	if err != nil {
+		return fmt.Errorf("kafka: new consumer group error:%s", err.Error())
+	}
+	defer group.Close()
+
+	handler := &kafkaHandler{rc: rc}
+	for {
+		if err := group.Consume(ctx, []string{k.topic}, handler); err != nil {
+			log.Println("kafka: consume error:", err.Error())
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+type kafkaHandler struct {
+	rc chan []byte
+}
+
+func (h *kafkaHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *kafkaHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *kafkaHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		line := make([]byte, len(msg.Value))
+		copy(line, msg.Value)
+		select {
+		case h.rc <- line:
+		case <-sess.Context().Done():
+			return nil
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+// Package message 定义日志处理流水线中各阶段之间传递的数据结构。
+package message
+
+import "time"
+
+// Message 是一条日志被解析后的结果,input/output 各个 backend 都依赖这个公共结构体,
+// 避免 parser 和 output 之间产生循环依赖。
+//
+// 字段是动态的:Tags 存放低基数的、适合当索引/标签用的值(比如 path、method、status),
+// Fields 存放数值型的度量值(比如 request_time、byte_send),具体有哪些 key 由 parser
+// 的配置决定,不再是写死的结构体字段。
+type Message struct {
+	TimeLocal time.Time
+	Tags      map[string]string
+	Fields    map[string]interface{}
+}
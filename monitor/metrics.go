@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 这里的 Prometheus 指标替换了原来靠 TypeMonitorChan 传递、在一个专门的 goroutine 里
+// 串行累加的计数器,现在各个阶段直接原子地更新自己的指标,监控不再是潜在的串行化瓶颈。
+var (
+	linesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "loganalytics_lines_total",
+		Help: "Total number of log lines successfully parsed.",
+	})
+	parseErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "loganalytics_parse_errors_total",
+		Help: "Total number of log lines that failed to parse.",
+	})
+	writesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "loganalytics_writes_total",
+		Help: "Total number of batches written to the output backend, labeled by status.",
+	}, []string{"status"})
+
+	readChanLenGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loganalytics_read_chan_len",
+		Help: "Current number of buffered raw lines waiting to be parsed.",
+	})
+	writeChanLenGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loganalytics_write_chan_len",
+		Help: "Current number of parsed messages waiting to be written.",
+	})
+	tpsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loganalytics_tps",
+		Help: "Lines parsed per second, averaged over the sampling window.",
+	})
+
+	parseLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "loganalytics_parse_duration_seconds",
+		Help:    "Time spent parsing a single log line.",
+		Buckets: prometheus.DefBuckets,
+	})
+	writeLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "loganalytics_write_duration_seconds",
+		Help:    "Time spent writing a single batch to the output backend.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	handleLines int64
+	errNum      int64
+)
+
+// RecordLine 记录一行日志解析成功,cost 是这一行花了多久解析
+func RecordLine(cost time.Duration) {
+	atomic.AddInt64(&handleLines, 1)
+	linesTotal.Inc()
+	parseLatency.Observe(cost.Seconds())
+}
+
+// RecordParseError 记录一行日志解析失败
+func RecordParseError() {
+	atomic.AddInt64(&errNum, 1)
+	parseErrorsTotal.Inc()
+}
+
+// RecordWrite 记录一次批量写入,status 通常是 "success" 或者 "error"
+func RecordWrite(status string, cost time.Duration) {
+	writesTotal.WithLabelValues(status).Inc()
+	writeLatency.Observe(cost.Seconds())
+}
@@ -0,0 +1,228 @@
+// Package monitor 对外暴露流水线的运行状态,包含一个兼容旧版的 /monitor JSON 接口
+// 和标准的 /metrics Prometheus 接口。
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type SystemInfo struct {
+	HandleLine    int     `json:"HandleLine"`    // 总处理日志行数
+	Tps           float64 `json:"tps"`           // 系统吞吐量
+	ReadChanLen   int     `json:"readChanLen"`   // read channel 长度
+	WriteChanLen  int     `json:"writeChanLen"`  // write channel 长度
+	RunTime       string  `json:"runtime"`       // 运行总时间
+	ErrNum        int     `json:"errNum"`        // 错误数
+	CheckpointLag int64   `json:"checkpointLag"` // file tailer 落后文件末尾的字节数,非 file input 时恒为0
+
+	// 下面几个只有 -output=influxdb 时才有意义,其它 output backend 恒为0
+	BatchesFlushed int64 `json:"batchesFlushed"` // 成功写入 influxdb 的批次数
+	PointsDropped  int64 `json:"pointsDropped"`  // spool 溢出或序列化失败丢弃的点数
+	RetryCount     int64 `json:"retryCount"`     // 写入 influxdb 的重试次数
+	SpoolBytes     int64 `json:"spoolBytes"`     // 落盘等待补发的字节数
+}
+
+const (
+	sampleInterval = 5 * time.Second
+	windowSamples  = 6 // 最近 6 个采样点,也就是 30s 的滑动窗口
+)
+
+// ChanLenFunc 由调用方提供,用来读取 read/write channel 当前的长度
+type ChanLenFunc func() int
+
+// CheckpointLagFunc 由调用方提供,用来读取 file tailer 当前落后文件末尾多少字节
+type CheckpointLagFunc func() int64
+
+// InfluxStats 是 -output=influxdb 时写入端的统计数据
+type InfluxStats struct {
+	BatchesFlushed int64
+	PointsDropped  int64
+	RetryCount     int64
+	SpoolBytes     int64
+}
+
+// InfluxStatsFunc 由调用方提供,用来读取 influxdb writer 当前的统计数据
+type InfluxStatsFunc func() InfluxStats
+
+// HealthFunc 由调用方提供,报告流水线的 worker 是否健康(比如没有反复重启失败),
+// 供 /healthz 使用。
+type HealthFunc func() bool
+
+// ReadyFunc 由调用方提供,报告流水线是否已经就绪(启动完成、没有在关闭过程中),
+// 供 /readyz 使用。
+type ReadyFunc func() bool
+
+type Monitor struct {
+	startTime time.Time
+
+	tpsMu  sync.Mutex
+	tpsSli []int64
+
+	readChanLen   ChanLenFunc
+	writeChanLen  ChanLenFunc
+	checkpointLag CheckpointLagFunc
+	influxStats   InfluxStatsFunc
+	healthy       HealthFunc
+	ready         ReadyFunc
+}
+
+// New 构造一个 Monitor,readChanLen/writeChanLen 用来在 /monitor 里报告 channel 积压,
+// checkpointLag/influxStats 分别报告 file tailer 和 influxdb writer 的统计数据,
+// healthy/ready 分别驱动 /healthz 和 /readyz,都不关心时传 nil
+func New(readChanLen, writeChanLen ChanLenFunc, checkpointLag CheckpointLagFunc, influxStats InfluxStatsFunc, healthy HealthFunc, ready ReadyFunc) *Monitor {
+	return &Monitor{
+		startTime:     time.Now(),
+		readChanLen:   readChanLen,
+		writeChanLen:  writeChanLen,
+		checkpointLag: checkpointLag,
+		influxStats:   influxStats,
+		healthy:       healthy,
+		ready:         ready,
+	}
+}
+
+// recordSample 把一次采样点追加到滑动窗口里,窗口满了就丢掉最旧的一个,
+// 并在有至少两个采样点时算出当前的 tps。tpsSli 会被采样 goroutine 写、
+// /monitor handler 读,所以两边都要经过 tpsMu。
+func (m *Monitor) recordSample(cur int64) (float64, bool) {
+	m.tpsMu.Lock()
+	defer m.tpsMu.Unlock()
+
+	m.tpsSli = append(m.tpsSli, cur)
+	if len(m.tpsSli) > windowSamples {
+		m.tpsSli = m.tpsSli[1:]
+	}
+	if len(m.tpsSli) < 2 {
+		return 0, false
+	}
+	delta := m.tpsSli[len(m.tpsSli)-1] - m.tpsSli[0]
+	seconds := float64(len(m.tpsSli)-1) * sampleInterval.Seconds()
+	return float64(delta) / seconds, true
+}
+
+func (m *Monitor) tps() (float64, bool) {
+	m.tpsMu.Lock()
+	defer m.tpsMu.Unlock()
+
+	if len(m.tpsSli) < 2 {
+		return 0, false
+	}
+	delta := m.tpsSli[len(m.tpsSli)-1] - m.tpsSli[0]
+	seconds := float64(len(m.tpsSli)-1) * sampleInterval.Seconds()
+	return float64(delta) / seconds, true
+}
+
+const shutdownTimeout = 5 * time.Second
+
+// Start 启动统计采样和 HTTP admin server,阻塞直到 ctx 被取消并且 server 完成 Shutdown。
+func (m *Monitor) Start(ctx context.Context) error {
+
+	// 定时采样 handleLines,在一个固定大小的滑动窗口上算吞吐量,
+	// 而不是像原来那样只看两个点、而且只给第二个点除了5。
+	ticker := time.NewTicker(sampleInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cur := atomic.LoadInt64(&handleLines)
+				if tps, ok := m.recordSample(cur); ok {
+					tpsGauge.Set(tps)
+				}
+			}
+		}
+	}()
+
+	// 定时刷新 channel 长度的 gauge,这样即使没人访问 /monitor,/metrics 也能看到实时积压
+	chanTicker := time.NewTicker(1 * time.Second)
+	go func() {
+		defer chanTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-chanTicker.C:
+				readChanLenGauge.Set(float64(m.readChanLen()))
+				writeChanLenGauge.Set(float64(m.writeChanLen()))
+			}
+		}
+	}()
+
+	http.HandleFunc("/monitor", func(writer http.ResponseWriter, request *http.Request) {
+		data := SystemInfo{
+			HandleLine:   int(atomic.LoadInt64(&handleLines)),
+			ErrNum:       int(atomic.LoadInt64(&errNum)),
+			RunTime:      time.Now().Sub(m.startTime).String(),
+			ReadChanLen:  m.readChanLen(),
+			WriteChanLen: m.writeChanLen(),
+		}
+
+		if tps, ok := m.tps(); ok {
+			data.Tps = tps
+		}
+
+		if m.checkpointLag != nil {
+			data.CheckpointLag = m.checkpointLag()
+		}
+		if m.influxStats != nil {
+			stats := m.influxStats()
+			data.BatchesFlushed = stats.BatchesFlushed
+			data.PointsDropped = stats.PointsDropped
+			data.RetryCount = stats.RetryCount
+			data.SpoolBytes = stats.SpoolBytes
+		}
+
+		ret, _ := json.MarshalIndent(data, "", "\t")
+		io.WriteString(writer, string(ret))
+	})
+
+	http.Handle("/metrics", promhttp.Handler())
+
+	http.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
+		if m.healthy != nil && !m.healthy() {
+			http.Error(writer, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(writer, "ok")
+	})
+
+	http.HandleFunc("/readyz", func(writer http.ResponseWriter, request *http.Request) {
+		if m.ready != nil && !m.ready() {
+			http.Error(writer, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(writer, "ok")
+	})
+
+	srv := &http.Server{Addr: ":9193"}
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+			return
+		}
+		serveErr <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return <-serveErr
+	case err := <-serveErr:
+		return err
+	}
+}
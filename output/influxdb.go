@@ -0,0 +1,304 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/influxdb1-client/v2"
+
+	"LogAnalytics/message"
+	"LogAnalytics/monitor"
+)
+
+// influxWriteTimeout 给 influxdb HTTP client 设一个上限,避免服务端挂起时写 goroutine 永久卡死,
+// 拿不到 ctx 取消的机会。
+const influxWriteTimeout = 10 * time.Second
+
+func init() {
+	Register("influxdb", func(cfg Config) (Write, error) {
+		if cfg.InfluxDBDsn == "" {
+			return nil, fmt.Errorf("influxdb output: -influxDsn is required")
+		}
+		spoolPath := cfg.InfluxSpoolPath
+		if spoolPath == "" {
+			spoolPath = "./influxdb.spool"
+		}
+		return &WriteToInfluxDB{
+			influxDBDsn:  cfg.InfluxDBDsn,
+			batchSize:    1000,
+			maxAge:       1 * time.Second,
+			maxRetries:   5,
+			spoolPath:    spoolPath,
+			spoolMaxSize: 64 * 1024 * 1024, // 64MB
+		}, nil
+	})
+}
+
+// 下面这几个计数器对外通过 BatchesFlushed/PointsDropped/RetryCount/SpoolBytes 暴露,
+// 供 monitor 的 SystemInfo 展示,命名和 input 包里的 CheckpointLagBytes 保持同样的风格。
+var (
+	batchesFlushed int64
+	pointsDropped  int64
+	retryCount     int64
+	spoolBytes     int64
+)
+
+func BatchesFlushed() int64 { return atomic.LoadInt64(&batchesFlushed) }
+func PointsDropped() int64  { return atomic.LoadInt64(&pointsDropped) }
+func RetryCount() int64     { return atomic.LoadInt64(&retryCount) }
+func SpoolBytes() int64     { return atomic.LoadInt64(&spoolBytes) }
+
+// WriteToInfluxDB 用于存储influxDB数据库源的结构体，influxDBDsn 相当于面向对象中的类属性
+type WriteToInfluxDB struct {
+	influxDBDsn string // influxDB data source
+
+	batchSize    int           // 攒够这么多个点就立刻 flush
+	maxAge       time.Duration // 攒够这么久也 flush,即使还没到 batchSize
+	maxRetries   int           // flush 失败之后重试几次
+	spoolPath    string        // 重试耗尽之后落盘的文件路径
+	spoolMaxSize int64         // spool 文件允许的最大字节数,超出的部分按 FIFO 丢弃
+
+	spoolMu    sync.Mutex // 多个写goroutine共用同一个 spool 文件,落盘/补发都要串行化
+	replayOnce sync.Once  // 多个写goroutine共享同一个 WriteToInfluxDB,只需要补发一次
+}
+
+// spooledPoint 是落盘时用的结构,字段和 client.NewPoint 的参数一一对应
+type spooledPoint struct {
+	Measurement string                 `json:"measurement"`
+	Tags        map[string]string      `json:"tags"`
+	Fields      map[string]interface{} `json:"fields"`
+	Time        time.Time              `json:"time"`
+}
+
+// Write方法，用于写入日志到数据库，绑定到了WriteToInfluxDB结构体中，相当于面向对象中的类方法
+// 若要使用，实例化WriteToInfluxDB结构体后，w = WriteToInfluxDB{init something}, w.Write(ctx, arg chan *message.Message)。
+// ctx 被取消时把已经攒下的点连同 wc 里剩下的一起 flush 掉再返回 nil。
+func (w *WriteToInfluxDB) Write(ctx context.Context, wc chan *message.Message) error {
+	infSli := strings.Split(w.influxDBDsn, "@")
+
+	c, err := client.NewHTTPClient(client.HTTPConfig{
+		Addr:     infSli[0],
+		Username: infSli[1],
+		Password: infSli[2],
+		Timeout:  influxWriteTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	database, precision := infSli[3], infSli[4]
+
+	// 启动时先把之前落盘但还没写成功的点补发一遍,多个写goroutine共用同一个 WriteToInfluxDB,
+	// sync.Once 保证只有第一个跑到这里的goroutine真正执行补发
+	w.replayOnce.Do(func() { w.replaySpool(c, database, precision) })
+
+	points := make([]*client.Point, 0, w.batchSize)
+	ticker := time.NewTicker(w.maxAge)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(points) == 0 {
+			return
+		}
+		start := time.Now()
+		if err := w.flushWithRetry(c, database, precision, points); err != nil {
+			log.Println("influxdb: flush failed after retries, spooling:", err.Error())
+			w.spool(points)
+			monitor.RecordWrite("error", time.Since(start))
+		} else {
+			atomic.AddInt64(&batchesFlushed, 1)
+			monitor.RecordWrite("success", time.Since(start))
+		}
+		points = points[:0]
+	}
+
+	addPoint := func(v *message.Message) {
+		pt, err := client.NewPoint(database+"_log", v.Tags, v.Fields, v.TimeLocal)
+		if err != nil {
+			log.Println("influxdb: new point error:", err.Error())
+			return
+		}
+		points = append(points, pt)
+		if len(points) >= w.batchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 排空 wc 里已经读进来但还没写的消息,再 flush 一次,尽量不丢数据
+			for {
+				select {
+				case v, ok := <-wc:
+					if !ok {
+						flush()
+						return nil
+					}
+					addPoint(v)
+				default:
+					flush()
+					return nil
+				}
+			}
+		case v, ok := <-wc:
+			if !ok {
+				flush()
+				return nil
+			}
+			addPoint(v)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flushWithRetry 把一批点一次性写进 influxdb,失败时按指数退避加抖动重试 maxRetries 次
+func (w *WriteToInfluxDB) flushWithRetry(c client.Client, database, precision string, points []*client.Point) error {
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{Database: database, Precision: precision})
+	if err != nil {
+		return err
+	}
+	bp.AddPoints(points)
+
+	var lastErr error
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			atomic.AddInt64(&retryCount, 1)
+			backoff := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+			jitter := time.Duration(rand.Int63n(int64(backoff) + 1))
+			time.Sleep(backoff + jitter)
+		}
+
+		if err := c.Write(bp); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// spool 把写失败的点追加到本地文件,replaySpool 在下次重连成功之后补发,
+// 超过 spoolMaxSize 时按 FIFO 丢弃最旧的数据,避免磁盘被写满。
+func (w *WriteToInfluxDB) spool(points []*client.Point) {
+	w.spoolMu.Lock()
+	defer w.spoolMu.Unlock()
+
+	f, err := os.OpenFile(w.spoolPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println("influxdb: open spool file error:", err.Error())
+		atomic.AddInt64(&pointsDropped, int64(len(points)))
+		return
+	}
+	defer f.Close()
+
+	for _, pt := range points {
+		fields, err := pt.Fields()
+		if err != nil {
+			atomic.AddInt64(&pointsDropped, 1)
+			continue
+		}
+		sp := spooledPoint{Measurement: pt.Name(), Tags: pt.Tags(), Fields: fields, Time: pt.Time()}
+		data, err := json.Marshal(sp)
+		if err != nil {
+			atomic.AddInt64(&pointsDropped, 1)
+			continue
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			log.Println("influxdb: write spool file error:", err.Error())
+			atomic.AddInt64(&pointsDropped, 1)
+			continue
+		}
+	}
+
+	w.trimSpool()
+}
+
+// trimSpool 在 spool 文件超过 spoolMaxSize 时,从头部丢弃最旧的若干行
+func (w *WriteToInfluxDB) trimSpool() {
+	st, err := os.Stat(w.spoolPath)
+	if err != nil {
+		return
+	}
+	atomic.StoreInt64(&spoolBytes, st.Size())
+	if st.Size() <= w.spoolMaxSize {
+		return
+	}
+
+	data, err := os.ReadFile(w.spoolPath)
+	if err != nil {
+		return
+	}
+	overflow := int64(len(data)) - w.spoolMaxSize
+	cut := 0
+	for i, b := range data {
+		if int64(i) >= overflow && b == '\n' {
+			cut = i + 1
+			break
+		}
+		if b == '\n' {
+			atomic.AddInt64(&pointsDropped, 1)
+		}
+	}
+	if cut == 0 {
+		return
+	}
+	if err := os.WriteFile(w.spoolPath, data[cut:], 0644); err != nil {
+		log.Println("influxdb: trim spool file error:", err.Error())
+		return
+	}
+	atomic.StoreInt64(&spoolBytes, int64(len(data)-cut))
+}
+
+// replaySpool 在启动时尝试把 spool 文件里积压的点补发出去,成功发出的部分会被清空
+func (w *WriteToInfluxDB) replaySpool(c client.Client, database, precision string) {
+	w.spoolMu.Lock()
+	defer w.spoolMu.Unlock()
+
+	f, err := os.Open(w.spoolPath)
+	if err != nil {
+		return
+	}
+
+	var points []*client.Point
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var sp spooledPoint
+		if err := json.Unmarshal(scanner.Bytes(), &sp); err != nil {
+			continue
+		}
+		pt, err := client.NewPoint(sp.Measurement, sp.Tags, sp.Fields, sp.Time)
+		if err != nil {
+			continue
+		}
+		points = append(points, pt)
+	}
+	f.Close()
+
+	if len(points) == 0 {
+		return
+	}
+
+	if err := w.flushWithRetry(c, database, precision, points); err != nil {
+		log.Println("influxdb: replay spool failed, will retry later:", err.Error())
+		return
+	}
+
+	atomic.AddInt64(&batchesFlushed, 1)
+	if err := os.Remove(w.spoolPath); err != nil {
+		log.Println("influxdb: remove spool file error:", err.Error())
+	}
+	atomic.StoreInt64(&spoolBytes, 0)
+}
@@ -0,0 +1,197 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogAnalytics/message"
+	"LogAnalytics/monitor"
+)
+
+// lokiWriteTimeout 给 loki HTTP client 设一个上限,避免服务端挂起时写 goroutine 永久卡死,
+// 拿不到 ctx 取消的机会。
+const lokiWriteTimeout = 10 * time.Second
+
+func init() {
+	Register("loki", func(cfg Config) (Write, error) {
+		if cfg.LokiURL == "" {
+			return nil, fmt.Errorf("loki output: -loki-url is required")
+		}
+		return &WriteToLoki{
+			url:       cfg.LokiURL,
+			orgID:     cfg.LokiOrgID,
+			batchSize: 1000,
+			client:    http.Client{Timeout: lokiWriteTimeout},
+		}, nil
+	})
+}
+
+// lokiPushRequest 是 /loki/api/v1/push 接受的 JSON 格式,一个 stream 对应一组 labels,
+// values 是该 stream 下的 [timestamp, line] 列表。
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// WriteToLoki 把 message.Message 批量推送到 Grafana Loki,label 从 Path/Method/Status
+// 等 tag 字段派生,日志行内容就是原始 message 的简要描述。
+type WriteToLoki struct {
+	url       string // 例如 http://127.0.0.1:3100
+	orgID     string // X-Scope-OrgID,多租户时使用,单租户留空
+	batchSize int
+	client    http.Client
+}
+
+// Write方法,从 wc 中取出消息,按 batchSize 攒批,然后压缩推送给 loki。
+// ctx 被取消时排空 wc 里剩下的消息,flush 一次再返回 nil。
+func (w *WriteToLoki) Write(ctx context.Context, wc chan *message.Message) error {
+	streams := map[string]*lokiStream{}
+	count := 0
+
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		req := lokiPushRequest{}
+		for _, s := range streams {
+			req.Streams = append(req.Streams, *s)
+		}
+
+		start := time.Now()
+		if err := w.push(ctx, req); err != nil {
+			log.Println("loki: push error:", err.Error())
+			monitor.RecordWrite("error", time.Since(start))
+		} else {
+			monitor.RecordWrite("success", time.Since(start))
+		}
+		streams = map[string]*lokiStream{}
+		count = 0
+	}
+
+	addMessage := func(v *message.Message) {
+		labels := map[string]string{"job": "loganalytics"}
+		for k, val := range v.Tags {
+			labels[k] = val
+		}
+		key := labelsKey(labels)
+		s, ok := streams[key]
+		if !ok {
+			s = &lokiStream{Stream: labels}
+			streams[key] = s
+		}
+
+		line := formatLine(v.Tags, v.Fields)
+		ts := strconv.FormatInt(v.TimeLocal.UnixNano(), 10)
+		s.Values = append(s.Values, [2]string{ts, line})
+
+		count++
+		if count >= w.batchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case v, ok := <-wc:
+					if !ok {
+						flush()
+						return nil
+					}
+					addMessage(v)
+				default:
+					flush()
+					return nil
+				}
+			}
+		case v, ok := <-wc:
+			if !ok {
+				flush()
+				return nil
+			}
+			addMessage(v)
+		}
+	}
+}
+
+// labelsKey 把 labels 拼成一个确定顺序的字符串,用来把同一组 labels 归并到同一个 stream 里
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte('|')
+	}
+	return b.String()
+}
+
+// formatLine 把 tags/fields 拼成一行 logfmt 风格的文本,作为 loki 里看到的日志内容
+func formatLine(tags map[string]string, fields map[string]interface{}) string {
+	var b strings.Builder
+	for k, v := range tags {
+		fmt.Fprintf(&b, "%s=%s ", k, v)
+	}
+	for k, v := range fields {
+		fmt.Fprintf(&b, "%s=%v ", k, v)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (w *WriteToLoki) push(ctx context.Context, req lokiPushRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url+"/loki/api/v1/push", &buf)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Encoding", "gzip")
+	if w.orgID != "" {
+		httpReq.Header.Set("X-Scope-OrgID", w.orgID)
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("loki: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
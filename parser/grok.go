@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+
+	"LogAnalytics/config"
+)
+
+// grokPatterns 是一个小型的、可复用的 grok 模式库,足够覆盖常见的访问日志字段。
+// 参考 logstash 内置的 grok-patterns,只挑了用得到的几个。
+var grokPatterns = map[string]string{
+	"IPORHOST":   `(?:[0-9a-fA-F.:]+|[a-zA-Z0-9._-]+)`,
+	"HTTPDATE":   `\d{2}/[A-Za-z]{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"NUMBER":     `[+-]?(?:\d+(?:\.\d+)?)`,
+	"WORD":       `\b\w+\b`,
+	"QS":         `"(?:[^"\\]|\\.)*"`,
+	"DATA":       `.*?`,
+	"GREEDYDATA": `.*`,
+}
+
+var grokRefRe = regexp.MustCompile(`%\{(\w+)(?::(\w+))?\}`)
+
+// compileGrokPattern 把 %{PATTERN:name} 形式的引用展开成 Go 正则的命名捕获组,
+// 比如 %{IPORHOST:remote_addr} 变成 (?P<remote_addr>(?:[0-9a-fA-F.:]+|...))
+func compileGrokPattern(pattern string) (string, error) {
+	var missing string
+	expanded := grokRefRe.ReplaceAllStringFunc(pattern, func(ref string) string {
+		parts := grokRefRe.FindStringSubmatch(ref)
+		patternName, fieldName := parts[1], parts[2]
+
+		frag, ok := grokPatterns[patternName]
+		if !ok {
+			missing = patternName
+			return ref
+		}
+		if fieldName == "" {
+			return "(?:" + frag + ")"
+		}
+		return fmt.Sprintf("(?P<%s>%s)", fieldName, frag)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("grok parser: unknown pattern %%{%s}", missing)
+	}
+	return expanded, nil
+}
+
+func newGrokParser(cfg config.ParserConfig) (*RegexParser, error) {
+	if cfg.Pattern == "" {
+		return nil, fmt.Errorf("grok parser: pattern is required")
+	}
+
+	expanded, err := compileGrokPattern(cfg.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("grok parser: compile expanded pattern %q: %w", expanded, err)
+	}
+
+	return newRegexParserFromRegexp(re, cfg)
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+
+	"LogAnalytics/config"
+)
+
+func TestCompileGrokPattern(t *testing.T) {
+	expanded, err := compileGrokPattern(`%{IPORHOST:remote_addr} %{NUMBER:status}`)
+	if err != nil {
+		t.Fatalf("compileGrokPattern: %v", err)
+	}
+	if _, err := newRegexParser(config.ParserConfig{Pattern: expanded}); err != nil {
+		t.Fatalf("expanded pattern does not compile: %v", err)
+	}
+}
+
+func TestCompileGrokPatternUnknown(t *testing.T) {
+	if _, err := compileGrokPattern(`%{NOPE:field}`); err == nil {
+		t.Fatal("expected an error for an unknown grok pattern")
+	}
+}
+
+func TestGrokParserFieldMapping(t *testing.T) {
+	cfg := config.ParserConfig{
+		Pattern:    `%{IPORHOST:remote_addr} - "%{WORD:method} %{DATA:path} HTTP/%{NUMBER}" %{NUMBER:status}`,
+		TimeField:  "",
+		TimeLayout: "",
+		Fields: []config.FieldConfig{
+			{Name: "remote_addr", Column: "tag"},
+			{Name: "method", Column: "tag"},
+			{Name: "path", Column: "tag"},
+			{Name: "status", Column: "field", Type: "int"},
+		},
+	}
+
+	p, err := newGrokParser(cfg)
+	if err != nil {
+		t.Fatalf("newGrokParser: %v", err)
+	}
+
+	m, err := p.Parse([]byte(`192.168.1.1 - "GET /health HTTP/1.1" 204`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if m.Tags["remote_addr"] != "192.168.1.1" || m.Tags["method"] != "GET" || m.Tags["path"] != "/health" {
+		t.Errorf("Tags = %+v", m.Tags)
+	}
+	if status, ok := m.Fields["status"].(int); !ok || status != 204 {
+		t.Errorf("Fields[status] = %#v, want int 204", m.Fields["status"])
+	}
+}
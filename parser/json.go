@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"LogAnalytics/config"
+	"LogAnalytics/message"
+)
+
+// JSONParser 解析结构化的 JSON-lines 日志,每行一个 JSON 对象,字段去向同样由
+// config.FieldConfig 决定,TimeField 对应的 json key 会被解析成 TimeLocal。
+type JSONParser struct {
+	fields     map[string]config.FieldConfig
+	timeField  string
+	timeLayout string
+	location   *time.Location
+}
+
+func newJSONParser(cfg config.ParserConfig) (*JSONParser, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("json parser: load location %q: %w", cfg.Timezone, err)
+		}
+		location = loc
+	}
+
+	fields := make(map[string]config.FieldConfig, len(cfg.Fields))
+	for _, f := range cfg.Fields {
+		fields[f.Name] = f
+	}
+
+	return &JSONParser{
+		fields:     fields,
+		timeField:  cfg.TimeField,
+		timeLayout: cfg.TimeLayout,
+		location:   location,
+	}, nil
+}
+
+// Parse 把一行 json 反序列化成 map,再按配置把各个 key 分到 Tags 或者 Fields 里
+func (p *JSONParser) Parse(line []byte) (*message.Message, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, fmt.Errorf("json parser: unmarshal: %w", err)
+	}
+
+	m := &message.Message{
+		Tags:   map[string]string{},
+		Fields: map[string]interface{}{},
+	}
+
+	for name, value := range raw {
+		if name == p.timeField {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("json parser: time field %q is not a string", name)
+			}
+			t, err := time.ParseInLocation(p.timeLayout, s, p.location)
+			if err != nil {
+				return nil, fmt.Errorf("json parser: parse time %q: %w", s, err)
+			}
+			m.TimeLocal = t
+			continue
+		}
+
+		fc, ok := p.fields[name]
+		if !ok {
+			continue
+		}
+
+		if fc.Column == "field" {
+			// json 数字/布尔本来就已经是对应的 go 类型,只有字段被上游序列化成
+			// 字符串(比如某些日志采集器统一把数字发成字符串)时才需要按 fc.Type 转换,
+			// 和 regex/grok 解析器对捕获到的字符串做的事情保持一致。
+			if s, ok := value.(string); ok {
+				m.Fields[name] = convertFieldValue(s, fc.Type)
+			} else {
+				m.Fields[name] = value
+			}
+		} else {
+			m.Tags[name] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return m, nil
+}
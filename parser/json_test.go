@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"LogAnalytics/config"
+)
+
+func TestJSONParserFieldMapping(t *testing.T) {
+	cfg := config.ParserConfig{
+		TimeField:  "time",
+		TimeLayout: time.RFC3339,
+		Fields: []config.FieldConfig{
+			{Name: "method", Column: "tag"},
+			{Name: "path", Column: "tag"},
+			{Name: "status", Column: "field", Type: "int"},
+			{Name: "request_time", Column: "field", Type: "float"},
+		},
+	}
+
+	p, err := newJSONParser(cfg)
+	if err != nil {
+		t.Fatalf("newJSONParser: %v", err)
+	}
+
+	line := `{"time":"2024-01-02T03:04:05Z","method":"GET","path":"/api","status":200,"request_time":0.5}`
+	m, err := p.Parse([]byte(line))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	wantTime, _ := time.ParseInLocation(cfg.TimeLayout, "2024-01-02T03:04:05Z", time.UTC)
+	if !m.TimeLocal.Equal(wantTime) {
+		t.Errorf("TimeLocal = %v, want %v", m.TimeLocal, wantTime)
+	}
+	if m.Tags["method"] != "GET" || m.Tags["path"] != "/api" {
+		t.Errorf("Tags = %+v", m.Tags)
+	}
+	if status, ok := m.Fields["status"].(float64); !ok || status != 200 {
+		t.Errorf("Fields[status] = %#v, want native json float64 200", m.Fields["status"])
+	}
+}
+
+// TestJSONParserHonorsTypeForStringEncodedFields covers the case where a log
+// shipper serializes a numeric field as a JSON string; the configured Type
+// should still be applied, matching what the regex/grok parsers do for the
+// same FieldConfig.
+func TestJSONParserHonorsTypeForStringEncodedFields(t *testing.T) {
+	cfg := config.ParserConfig{
+		Fields: []config.FieldConfig{
+			{Name: "status", Column: "field", Type: "int"},
+			{Name: "request_time", Column: "field", Type: "float"},
+		},
+	}
+
+	p, err := newJSONParser(cfg)
+	if err != nil {
+		t.Fatalf("newJSONParser: %v", err)
+	}
+
+	line := `{"status":"200","request_time":"0.5"}`
+	m, err := p.Parse([]byte(line))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if status, ok := m.Fields["status"].(int); !ok || status != 200 {
+		t.Errorf("Fields[status] = %#v, want int 200", m.Fields["status"])
+	}
+	if rt, ok := m.Fields["request_time"].(float64); !ok || rt != 0.5 {
+		t.Errorf("Fields[request_time] = %#v, want float64 0.5", m.Fields["request_time"])
+	}
+}
+
+func TestJSONParserUnmappedFieldIsDropped(t *testing.T) {
+	p, err := newJSONParser(config.ParserConfig{})
+	if err != nil {
+		t.Fatalf("newJSONParser: %v", err)
+	}
+
+	m, err := p.Parse([]byte(`{"unmapped":"value"}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(m.Tags) != 0 || len(m.Fields) != 0 {
+		t.Errorf("expected unmapped field to be dropped, got Tags=%+v Fields=%+v", m.Tags, m.Fields)
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"regexp"
+
+	"LogAnalytics/config"
+)
+
+// nginxPattern 是内置的默认日志格式,和这个仓库最初支持的 nginx access log 格式一致,
+// 方便不写 -config 也能直接跑起来。
+const nginxPattern = `([\d\,]+)\s+([^ \[]+)\s+([^ \[]+)\s+\[(?P<time_local>[^\]]+)\]\s+(?P<scheme>[a-z]+)\s+"(?P<method>[A-Z]+)\s+(?P<path>\S+)\s+[^"]*"\s+(?P<status>\d{3})\s+(?P<byte_send>\d+)\s+"[^"]*"\s+".*?"\s+"[\d\.-]+"\s+(?P<upstream_time>[\d\.-]+)\s+(?P<request_time>[\d\.-]+)`
+
+// NewNginxParser 构造一个按内置默认格式解析的 Parser,等价于以前写死在 LogProcess.Process
+// 里的那条正则表达式,只是现在字段的落地方式(Tags/Fields)是用同一套 RegexParser 引擎实现的。
+func NewNginxParser() *RegexParser {
+	p, err := newRegexParserFromRegexp(nginxRe, config.ParserConfig{
+		TimeField:  "time_local",
+		TimeLayout: "02/Jan/2006:15:04:05 +0000",
+		Timezone:   "Asia/Shanghai",
+		Fields: []config.FieldConfig{
+			{Name: "scheme", Column: "tag"},
+			{Name: "method", Column: "tag"},
+			{Name: "path", Column: "tag"},
+			{Name: "status", Column: "tag"},
+			{Name: "byte_send", Column: "field", Type: "int"},
+			{Name: "upstream_time", Column: "field", Type: "float"},
+			{Name: "request_time", Column: "field", Type: "float"},
+		},
+	})
+	if err != nil {
+		// 内置格式是编译期常量,理论上不可能出错
+		panic(err)
+	}
+	return p
+}
+
+var nginxRe = regexp.MustCompile(nginxPattern)
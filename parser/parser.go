@@ -0,0 +1,44 @@
+// Package parser 把一行原始日志解析成 message.Message,具体的日志格式由 Parser 的
+// 实现决定,通过 -config app.yaml 里 parser.type 选择 regex、grok 或者 json。
+package parser
+
+import (
+	"fmt"
+
+	"LogAnalytics/config"
+	"LogAnalytics/message"
+)
+
+// Parser 接口 定义了把一行日志解析成 message.Message 的规范
+type Parser interface {
+	Parse(line []byte) (*message.Message, error)
+}
+
+// New 根据配置构造一个 Parser,cfg.Type 为空时退化成内置的 nginx 预设,
+// 这样不写 -config 也能像以前一样直接跑起来。
+func New(cfg config.ParserConfig) (Parser, error) {
+	switch cfg.Type {
+	case "", "nginx":
+		return NewNginxParser(), nil
+	case "regex":
+		return newRegexParser(cfg)
+	case "grok":
+		return newGrokParser(cfg)
+	case "json":
+		return newJSONParser(cfg)
+	default:
+		return nil, fmt.Errorf("parser: unknown type %q", cfg.Type)
+	}
+}
+
+// convertFieldValue 把捕获到的原始字符串按 FieldConfig.Type 转换成落到 Fields 里的值
+func convertFieldValue(raw string, typ string) interface{} {
+	switch typ {
+	case "int":
+		return atoiOrZero(raw)
+	case "float":
+		return atofOrZero(raw)
+	default:
+		return raw
+	}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"LogAnalytics/config"
+	"LogAnalytics/message"
+)
+
+// RegexParser 用一条带命名捕获组的正则表达式解析每一行日志,捕获组的名字通过
+// config.FieldConfig 映射到 Tags 或者 Fields 里,是 grok 和内置 nginx 预设的底层实现。
+type RegexParser struct {
+	re         *regexp.Regexp
+	fields     map[string]config.FieldConfig
+	timeField  string
+	timeLayout string
+	location   *time.Location
+}
+
+func newRegexParser(cfg config.ParserConfig) (*RegexParser, error) {
+	if cfg.Pattern == "" {
+		return nil, fmt.Errorf("regex parser: pattern is required")
+	}
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("regex parser: compile pattern: %w", err)
+	}
+	return newRegexParserFromRegexp(re, cfg)
+}
+
+func newRegexParserFromRegexp(re *regexp.Regexp, cfg config.ParserConfig) (*RegexParser, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("regex parser: load location %q: %w", cfg.Timezone, err)
+		}
+		location = loc
+	}
+
+	fields := make(map[string]config.FieldConfig, len(cfg.Fields))
+	for _, f := range cfg.Fields {
+		fields[f.Name] = f
+	}
+
+	return &RegexParser{
+		re:         re,
+		fields:     fields,
+		timeField:  cfg.TimeField,
+		timeLayout: cfg.TimeLayout,
+		location:   location,
+	}, nil
+}
+
+// Parse 把命名捕获组的值分别塞进 Tags 或者 Fields,timeField 对应的捕获组用来生成 TimeLocal
+func (p *RegexParser) Parse(line []byte) (*message.Message, error) {
+	match := p.re.FindStringSubmatch(string(line))
+	if match == nil {
+		return nil, fmt.Errorf("regex parser: no match: %s", string(line))
+	}
+
+	m := &message.Message{
+		Tags:   map[string]string{},
+		Fields: map[string]interface{}{},
+	}
+
+	for i, name := range p.re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		value := match[i]
+
+		if name == p.timeField {
+			t, err := time.ParseInLocation(p.timeLayout, value, p.location)
+			if err != nil {
+				return nil, fmt.Errorf("regex parser: parse time %q: %w", value, err)
+			}
+			m.TimeLocal = t
+			continue
+		}
+
+		fc, ok := p.fields[name]
+		if !ok {
+			// 没有配置去向的捕获组直接丢弃
+			continue
+		}
+
+		if fc.Column == "field" {
+			m.Fields[name] = convertFieldValue(value, fc.Type)
+		} else {
+			m.Tags[name] = value
+		}
+	}
+
+	return m, nil
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func atofOrZero(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"LogAnalytics/config"
+)
+
+func TestRegexParserFieldMapping(t *testing.T) {
+	cfg := config.ParserConfig{
+		Pattern:    `(?P<time_local>\S+) (?P<method>\S+) (?P<path>\S+) (?P<status>\d+) (?P<request_time>[\d.]+)`,
+		TimeField:  "time_local",
+		TimeLayout: "2006-01-02T15:04:05",
+		Fields: []config.FieldConfig{
+			{Name: "method", Column: "tag"},
+			{Name: "path", Column: "tag"},
+			{Name: "status", Column: "field", Type: "int"},
+			{Name: "request_time", Column: "field", Type: "float"},
+		},
+	}
+
+	p, err := newRegexParser(cfg)
+	if err != nil {
+		t.Fatalf("newRegexParser: %v", err)
+	}
+
+	m, err := p.Parse([]byte("2024-01-02T03:04:05 GET /api/users 200 0.123"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	wantTime, _ := time.ParseInLocation(cfg.TimeLayout, "2024-01-02T03:04:05", time.UTC)
+	if !m.TimeLocal.Equal(wantTime) {
+		t.Errorf("TimeLocal = %v, want %v", m.TimeLocal, wantTime)
+	}
+	if m.Tags["method"] != "GET" || m.Tags["path"] != "/api/users" {
+		t.Errorf("Tags = %+v", m.Tags)
+	}
+	if status, ok := m.Fields["status"].(int); !ok || status != 200 {
+		t.Errorf("Fields[status] = %#v, want int 200", m.Fields["status"])
+	}
+	if rt, ok := m.Fields["request_time"].(float64); !ok || rt != 0.123 {
+		t.Errorf("Fields[request_time] = %#v, want float64 0.123", m.Fields["request_time"])
+	}
+}
+
+func TestRegexParserNoMatch(t *testing.T) {
+	p, err := newRegexParser(config.ParserConfig{Pattern: `^only-this$`})
+	if err != nil {
+		t.Fatalf("newRegexParser: %v", err)
+	}
+	if _, err := p.Parse([]byte("something else")); err == nil {
+		t.Fatal("expected an error when the line does not match")
+	}
+}
+
+func TestRegexParserUnmappedCaptureIsDropped(t *testing.T) {
+	p, err := newRegexParser(config.ParserConfig{Pattern: `(?P<kept>\S+) (?P<dropped>\S+)`})
+	if err != nil {
+		t.Fatalf("newRegexParser: %v", err)
+	}
+
+	m, err := p.Parse([]byte("a b"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := m.Tags["dropped"]; ok {
+		t.Errorf("capture group without a FieldConfig should not appear in Tags")
+	}
+}